@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/errgo.v1"
+
+	"github.com/codegangsta/negroni"
+)
+
+const (
+	// CommonLogFormat renders the classic Apache Common Log Format:
+	//   127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+	CommonLogFormat = `{{.ClientIP}} - - [{{.Time}}] "{{.Method}} {{.Path}} {{.Proto}}" {{.Status}} {{.Size}}`
+
+	// CombinedLogFormat is CommonLogFormat with the Referer and User-Agent
+	// headers appended, matching Apache/NCSA's Combined Log Format.
+	CombinedLogFormat = CommonLogFormat + ` "{{.Referer}}" "{{.UserAgent}}"`
+
+	accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+)
+
+// accessLogEntry is the data made available to an AccessLogMiddleware format
+// template.
+type accessLogEntry struct {
+	ClientIP  string
+	Method    string
+	Path      string
+	Proto     string
+	Status    int
+	Size      int
+	Referer   string
+	UserAgent string
+	Time      string
+	Duration  time.Duration
+}
+
+// AccessLogMiddleware writes one log line per request in an Apache-style
+// format (CommonLogFormat, CombinedLogFormat or a user-supplied
+// text/template string), so requests can be fed into existing
+// log-analysis pipelines such as GoAccess or AWStats.
+type AccessLogMiddleware struct {
+	out        io.Writer
+	tmpl       *template.Template
+	disableLog func(status int, r *http.Request) bool
+}
+
+// NewAccessLogMiddleware returns an AccessLogMiddleware that writes lines in
+// the given format to out. format may be one of the CommonLogFormat /
+// CombinedLogFormat constants, or any text/template string referencing the
+// accessLogEntry fields (ClientIP, Method, Path, Proto, Status, Size,
+// Referer, UserAgent, Time, Duration).
+func NewAccessLogMiddleware(out io.Writer, format string) (*AccessLogMiddleware, error) {
+	tmpl, err := template.New("accesslog").Parse(format)
+	if err != nil {
+		return nil, errgo.Notef(err, "invalid access log format %q", format)
+	}
+	return &AccessLogMiddleware{out: out, tmpl: tmpl}, nil
+}
+
+// DisableLog installs a hook that, when it returns true for a given
+// response status and request, skips writing that request's log line.
+// It is typically used to silence health-checks or other noisy endpoints.
+func (l *AccessLogMiddleware) DisableLog(f func(status int, r *http.Request) bool) *AccessLogMiddleware {
+	l.disableLog = f
+	return l
+}
+
+func (l *AccessLogMiddleware) Apply(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		before := time.Now()
+
+		rw := negroni.NewResponseWriter(w)
+		err := next(rw, r, vars)
+
+		status := rw.Status()
+		if status == 0 {
+			status = 200
+		}
+
+		if l.disableLog != nil && l.disableLog(status, r) {
+			return err
+		}
+
+		entry := accessLogEntry{
+			ClientIP:  clientIP(r),
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Proto:     r.Proto,
+			Status:    status,
+			Size:      rw.Size(),
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+			Time:      before.Format(accessLogTimeFormat),
+			Duration:  time.Since(before),
+		}
+
+		var buf bytes.Buffer
+		if tmplErr := l.tmpl.Execute(&buf, entry); tmplErr != nil {
+			// A malformed format string is a configuration problem with the
+			// middleware, not the handler it wraps; never let it shadow the
+			// handler's own error.
+			fmt.Fprintf(l.out, "access log: rendering entry: %v\n", tmplErr)
+			return err
+		}
+		buf.WriteByte('\n')
+		io.Copy(l.out, &buf)
+
+		return err
+	}
+}
+
+// clientIP resolves the originating client address for r, preferring the
+// left-most entry of X-Forwarded-For (set by upstream proxies/load
+// balancers), then X-Real-IP, and falling back to the host part of
+// r.RemoteAddr.
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		parts := strings.Split(fwd, ",")
+		if ip := strings.TrimSpace(parts[0]); ip != "" {
+			return ip
+		}
+	}
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
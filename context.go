@@ -0,0 +1,42 @@
+package handlers
+
+import "context"
+
+// contextKey is an unexported type so values set by this package can never
+// collide with keys set by other packages using the same string (the
+// previous "logger"/"request_id" string keys were flagged by go vet for
+// exactly this reason).
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	requestIDContextKey
+)
+
+// WithLogger returns a copy of ctx carrying logger, retrievable with
+// LoggerFromContext. It exists mainly so tests can set up a context
+// without going through the full middleware chain.
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// LoggerFromContext returns the Logger previously stored by
+// LoggingMiddleware (or WithLogger), if any.
+func LoggerFromContext(ctx context.Context) (Logger, bool) {
+	logger, ok := ctx.Value(loggerContextKey).(Logger)
+	return logger, ok
+}
+
+// WithRequestID returns a copy of ctx carrying id, retrievable with
+// RequestIDFromContext. It exists mainly so tests can set up a context
+// without going through the full middleware chain.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the request id previously stored by
+// LoggingMiddleware (or WithRequestID), if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey).(string)
+	return id, ok
+}
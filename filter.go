@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"container/list"
+	"hash/fnv"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// intervalLRUCapacity bounds the number of method+path keys tracked for
+// MinInterval suppression, so a service with an unbounded set of paths
+// (e.g. ids embedded in the URL) can't grow the cache without limit.
+const intervalLRUCapacity = 1024
+
+// FilterRule controls how requests matching a pattern are logged by
+// LoggingMiddleware.
+type FilterRule struct {
+	// Level is the logrus level used for requests matching this rule. The
+	// zero value (logrus.PanicLevel) is treated as "unset" and resolves to
+	// logrus.InfoLevel, matching the middleware's default — otherwise a
+	// FilterRule built only to set SampleRate or MinInterval would silently
+	// log matching requests at PanicLevel/"error" instead.
+	Level logrus.Level
+
+	// SampleRate, in [0.0, 1.0], is the fraction of matching requests that
+	// get logged. The zero value disables sampling (every matching request
+	// is logged); set Skip instead to drop a pattern entirely.
+	SampleRate float64
+
+	// MinInterval suppresses repeated log lines for the same method+path
+	// within the given window. The zero value disables suppression.
+	MinInterval time.Duration
+
+	// Skip, if true, drops matching requests' log lines entirely. Useful
+	// for health-checks and metrics endpoints.
+	Skip bool
+}
+
+type patternInfo struct {
+	re   *regexp.Regexp
+	rule FilterRule
+}
+
+// shouldSample deterministically decides whether a request should be
+// logged under rule's SampleRate, hashing requestID+path so that a
+// request's "starting"/"completed" pair either both log or both drop.
+func (rule FilterRule) shouldSample(requestID, path string) bool {
+	if rule.SampleRate <= 0 {
+		return true
+	}
+	if rule.SampleRate >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(requestID))
+	h.Write([]byte(path))
+	// Map the hash into [0, 1) and compare against the configured rate.
+	return float64(h.Sum32()%1000000)/1000000 < rule.SampleRate
+
+}
+
+// intervalLimiter is a small, size-bounded LRU tracking the last time a
+// method+path was logged, used to implement FilterRule.MinInterval.
+type intervalLimiter struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+}
+
+type intervalEntry struct {
+	key  string
+	seen time.Time
+}
+
+func newIntervalLimiter(capacity int) *intervalLimiter {
+	return &intervalLimiter{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// allow reports whether a log line for key may fire now, given minInterval.
+// It always returns true when minInterval is zero.
+func (l *intervalLimiter) allow(key string, minInterval time.Duration, now time.Time) bool {
+	if minInterval <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.entries[key]; ok {
+		entry := el.Value.(*intervalEntry)
+		l.order.MoveToFront(el)
+		if now.Sub(entry.seen) < minInterval {
+			return false
+		}
+		entry.seen = now
+		return true
+	}
+
+	el := l.order.PushFront(&intervalEntry{key: key, seen: now})
+	l.entries[key] = el
+	if l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		if oldest != nil {
+			l.order.Remove(oldest)
+			delete(l.entries, oldest.Value.(*intervalEntry).key)
+		}
+	}
+	return true
+}
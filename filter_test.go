@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFilterRuleShouldSample(t *testing.T) {
+	tests := []struct {
+		name string
+		rule FilterRule
+		want bool
+	}{
+		{name: "zero rate disables sampling (logs everything)", rule: FilterRule{SampleRate: 0}, want: true},
+		{name: "negative rate disables sampling", rule: FilterRule{SampleRate: -1}, want: true},
+		{name: "rate of 1 always logs", rule: FilterRule{SampleRate: 1}, want: true},
+		{name: "rate above 1 always logs", rule: FilterRule{SampleRate: 1.5}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.shouldSample("req-1", "/foo"); got != tt.want {
+				t.Errorf("shouldSample() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterRuleShouldSampleIsStable(t *testing.T) {
+	rule := FilterRule{SampleRate: 0.5}
+
+	first := rule.shouldSample("req-1", "/foo")
+	for i := 0; i < 100; i++ {
+		if got := rule.shouldSample("req-1", "/foo"); got != first {
+			t.Fatalf("shouldSample() is not stable for the same request_id+path: got %v, want %v", got, first)
+		}
+	}
+}
+
+func TestFilterRuleShouldSampleVariesByInput(t *testing.T) {
+	rule := FilterRule{SampleRate: 0.5}
+
+	sampled := 0
+	const n = 2000
+	for i := 0; i < n; i++ {
+		if rule.shouldSample(intToRequestID(i), "/foo") {
+			sampled++
+		}
+	}
+
+	// With a deterministic hash over varied input, a 0.5 rate should land
+	// in a broad middle band rather than always/never sampling.
+	if sampled == 0 || sampled == n {
+		t.Fatalf("shouldSample() looks constant across %d distinct ids: sampled=%d", n, sampled)
+	}
+}
+
+func intToRequestID(i int) string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, 8)
+	for j := range b {
+		b[j] = digits[(i>>(j*4))&0xf]
+	}
+	return string(b)
+}
+
+func TestIntervalLimiterAllow(t *testing.T) {
+	l := newIntervalLimiter(10)
+	now := time.Now()
+
+	if !l.allow("GET /foo", 0, now) {
+		t.Error("allow() with zero MinInterval should always return true")
+	}
+
+	if !l.allow("GET /foo", time.Minute, now) {
+		t.Error("first allow() for a key should always return true")
+	}
+	if l.allow("GET /foo", time.Minute, now.Add(time.Second)) {
+		t.Error("allow() within MinInterval should return false")
+	}
+	if !l.allow("GET /foo", time.Minute, now.Add(2*time.Minute)) {
+		t.Error("allow() after MinInterval has elapsed should return true")
+	}
+}
+
+func TestIntervalLimiterEvictsOldestAtCapacity(t *testing.T) {
+	l := newIntervalLimiter(2)
+	now := time.Now()
+
+	l.allow("a", time.Minute, now)
+	l.allow("b", time.Minute, now)
+	l.allow("c", time.Minute, now) // evicts "a", the least recently used
+
+	if !l.allow("a", time.Minute, now.Add(time.Millisecond)) {
+		t.Error("expected 'a' to have been evicted and treated as unseen")
+	}
+	if l.allow("c", time.Minute, now.Add(time.Millisecond)) {
+		t.Error("expected 'c' to still be tracked (not evicted) and within MinInterval")
+	}
+}
+
+func TestCompilePatternFiltersDefaultsLevelToInfo(t *testing.T) {
+	filters, err := compilePatternFilters(map[string]FilterRule{
+		"^/api/foo$": {SampleRate: 1.0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 {
+		t.Fatalf("expected 1 compiled filter, got %d", len(filters))
+	}
+	if got := filters[0].rule.Level; got != logrus.InfoLevel {
+		t.Errorf("rule.Level = %v, want %v (a FilterRule that only sets SampleRate/MinInterval must not silently log at PanicLevel)", got, logrus.InfoLevel)
+	}
+}
+
+func TestIntervalLimiterConcurrentAllow(t *testing.T) {
+	l := newIntervalLimiter(64)
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l.allow(intToRequestID(i%8), time.Minute, now)
+		}(i)
+	}
+	wg.Wait()
+}
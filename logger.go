@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Logger is the minimal logging surface LoggingMiddleware (and friends)
+// depend on. It lets callers plug in whatever logging library they use
+// instead of hard-wiring logrus. NewLogrusLogger and NewSlogLogger adapt
+// the two most common choices; implement it directly to wire up anything
+// else.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+
+	// With returns a Logger that includes fields on every subsequent call.
+	With(fields map[string]interface{}) Logger
+}
+
+type logrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrusLogger adapts a logrus.FieldLogger to the Logger interface.
+func NewLogrusLogger(logger logrus.FieldLogger) Logger {
+	return &logrusLogger{logger: logger}
+}
+
+func (l *logrusLogger) Debugf(format string, args ...interface{}) { l.logger.Debugf(format, args...) }
+func (l *logrusLogger) Infof(format string, args ...interface{})  { l.logger.Infof(format, args...) }
+func (l *logrusLogger) Warnf(format string, args ...interface{})  { l.logger.Warnf(format, args...) }
+func (l *logrusLogger) Errorf(format string, args ...interface{}) { l.logger.Errorf(format, args...) }
+
+func (l *logrusLogger) With(fields map[string]interface{}) Logger {
+	return &logrusLogger{logger: l.logger.WithFields(fields)}
+}
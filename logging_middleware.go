@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"regexp"
 	"time"
@@ -13,42 +12,87 @@ import (
 )
 
 var (
-	loggerFuncMap = map[logrus.Level]func(logrus.FieldLogger, string, ...interface{}){
-		logrus.DebugLevel: logrus.FieldLogger.Debugf,
-		logrus.InfoLevel:  logrus.FieldLogger.Infof,
-		logrus.WarnLevel:  logrus.FieldLogger.Warnf,
-		logrus.ErrorLevel: logrus.FieldLogger.Errorf,
-		logrus.FatalLevel: logrus.FieldLogger.Fatalf,
-		logrus.PanicLevel: logrus.FieldLogger.Panicf,
+	// loggerFuncMap dispatches to the Logger method matching a logrus.Level.
+	// Logger has no Fatal/Panic equivalents (those terminate the process,
+	// which a request-scoped middleware must never do), so both map to
+	// Errorf.
+	loggerFuncMap = map[logrus.Level]func(Logger, string, ...interface{}){
+		logrus.DebugLevel: Logger.Debugf,
+		logrus.InfoLevel:  Logger.Infof,
+		logrus.WarnLevel:  Logger.Warnf,
+		logrus.ErrorLevel: Logger.Errorf,
+		logrus.FatalLevel: Logger.Errorf,
+		logrus.PanicLevel: Logger.Errorf,
 	}
 )
 
-type patternInfo struct {
-	re    *regexp.Regexp
-	level logrus.Level
-}
-
+// LoggingMiddleware logs a "starting request"/"request completed" pair for
+// every request, except where a matching FilterRule's Skip, SampleRate or
+// MinInterval suppresses both lines. If RecoveryMiddleware is also in use, mount it closer to
+// the handler (LoggingMiddleware.Apply(RecoveryMiddleware.Apply(handler)))
+// so a panic still unwinds back through this middleware and gets its
+// "request completed" line and request-scoped fields.
 type LoggingMiddleware struct {
-	logger  logrus.FieldLogger
-	filters []patternInfo
+	logger   Logger
+	filters  []patternInfo
+	interval *intervalLimiter
 }
 
+// NewLoggingMiddleware returns a LoggingMiddleware backed by logger. It is
+// a thin wrapper around NewLoggingMiddlewareWithLogger kept for backwards
+// compatibility with callers that only have a logrus.FieldLogger.
 func NewLoggingMiddleware(logger logrus.FieldLogger) Middleware {
-	m := &LoggingMiddleware{logger: logger, filters: []patternInfo{}}
-	return m
+	return NewLoggingMiddlewareWithLogger(NewLogrusLogger(logger))
+}
+
+// NewLoggingMiddlewareWithLogger returns a LoggingMiddleware backed by any
+// Logger implementation, e.g. NewSlogLogger for users on Go 1.21+ who want
+// to avoid a logrus dependency.
+func NewLoggingMiddlewareWithLogger(logger Logger) *LoggingMiddleware {
+	return &LoggingMiddleware{logger: logger, filters: []patternInfo{}, interval: newIntervalLimiter(intervalLRUCapacity)}
 }
 
+// NewLoggingMiddlewareWithFilters returns a LoggingMiddleware that logs
+// requests matching pattern at the given logrus.Level. It is kept for
+// backwards compatibility; use NewLoggingMiddlewareWithFilterRules for
+// sampling, MinInterval suppression or dropping patterns entirely.
 func NewLoggingMiddlewareWithFilters(logger logrus.FieldLogger, filters map[string]logrus.Level) (*LoggingMiddleware, error) {
-	refilters := []patternInfo{}
+	rules := make(map[string]FilterRule, len(filters))
 	for pattern, level := range filters {
+		rules[pattern] = FilterRule{Level: level}
+	}
+	return NewLoggingMiddlewareWithFilterRules(logger, rules)
+}
+
+// NewLoggingMiddlewareWithFilterRules returns a LoggingMiddleware whose
+// per-pattern behaviour (level, sampling, rate limiting, skipping) is
+// controlled by rules.
+func NewLoggingMiddlewareWithFilterRules(logger logrus.FieldLogger, rules map[string]FilterRule) (*LoggingMiddleware, error) {
+	refilters, err := compilePatternFilters(rules)
+	if err != nil {
+		return nil, err
+	}
+	m := &LoggingMiddleware{
+		logger:   NewLogrusLogger(logger),
+		filters:  refilters,
+		interval: newIntervalLimiter(intervalLRUCapacity),
+	}
+	return m, nil
+}
+
+func compilePatternFilters(rules map[string]FilterRule) ([]patternInfo, error) {
+	refilters := []patternInfo{}
+	for pattern, rule := range rules {
 		re, err := regexp.Compile(pattern)
 		if err != nil {
 			return nil, errgo.Notef(err, "invalid regexp '%v'", pattern)
 		}
-		refilters = append(refilters, patternInfo{re: re, level: level})
+		if rule.Level == 0 {
+			rule.Level = logrus.InfoLevel
+		}
+		refilters = append(refilters, patternInfo{re: re, rule: rule})
 	}
-	m := &LoggingMiddleware{logger: logger, filters: refilters}
-	return m, nil
+	return refilters, nil
 }
 
 func (l *LoggingMiddleware) Apply(next HandlerFunc) HandlerFunc {
@@ -56,14 +100,20 @@ func (l *LoggingMiddleware) Apply(next HandlerFunc) HandlerFunc {
 		logger := l.logger
 		before := time.Now()
 
-		id, ok := r.Context().Value("request_id").(string)
-		if ok {
-			logger = logger.WithField("request_id", id)
+		id, ok := RequestIDFromContext(r.Context())
+		if !ok {
+			id, ok = requestIDFromHeaders(r)
 		}
+		if !ok {
+			id = newRequestID()
+		}
+		logger = logger.With(map[string]interface{}{"request_id": id})
 
-		r = r.WithContext(context.WithValue(r.Context(), "logger", logger))
+		ctx := WithRequestID(r.Context(), id)
+		ctx = WithLogger(ctx, logger)
+		r = r.WithContext(ctx)
 
-		fields := logrus.Fields{
+		fields := map[string]interface{}{
 			"method":     r.Method,
 			"path":       r.URL.String(),
 			"host":       r.Host,
@@ -77,15 +127,24 @@ func (l *LoggingMiddleware) Apply(next HandlerFunc) HandlerFunc {
 				delete(fields, k)
 			}
 		}
-		logger = logger.WithFields(fields)
+		logger = logger.With(fields)
 
 		loglevel := logrus.InfoLevel
+		var rule FilterRule
 		for _, info := range l.filters {
 			if info.re.MatchString(r.URL.Path) {
-				loglevel = info.level
+				loglevel = info.rule.Level
+				rule = info.rule
 			}
 		}
-		loggerFuncMap[loglevel](logger, "starting request")
+
+		shouldLog := !rule.Skip &&
+			rule.shouldSample(id, r.URL.Path) &&
+			l.interval.allow(r.Method+" "+r.URL.Path, rule.MinInterval, before)
+
+		if shouldLog {
+			loggerFuncMap[loglevel](logger, "starting request")
+		}
 
 		rw := negroni.NewResponseWriter(w)
 		err := next(rw, r, vars)
@@ -96,12 +155,14 @@ func (l *LoggingMiddleware) Apply(next HandlerFunc) HandlerFunc {
 			status = 200
 		}
 
-		logger = logger.WithFields(logrus.Fields{
-			"status":   status,
-			"duration": after.Sub(before).Seconds(),
-			"bytes":    rw.Size(),
-		})
-		loggerFuncMap[loglevel](logger, "request completed")
+		if shouldLog {
+			logger = logger.With(map[string]interface{}{
+				"status":   status,
+				"duration": after.Sub(before).Seconds(),
+				"bytes":    rw.Size(),
+			})
+			loggerFuncMap[loglevel](logger, "request completed")
+		}
 
 		return err
 	}
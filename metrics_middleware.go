@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/codegangsta/negroni"
+)
+
+// DefaultMetricsBuckets are the histogram buckets (seconds) used for
+// http_request_duration_seconds when none are supplied to
+// NewMetricsMiddleware.
+var DefaultMetricsBuckets = prometheus.DefBuckets
+
+// MetricsMiddleware exports Prometheus metrics for every request, reusing
+// the same negroni.ResponseWriter wrapping trick as LoggingMiddleware.Apply
+// to observe status and response size.
+//
+// By default the "path" label is the raw r.URL.Path, which is a
+// cardinality-explosion risk for any route with an embedded id
+// (/users/123, /users/456, ...) — each distinct URL becomes its own time
+// series. Call RouteLabel to collapse paths to their registered route
+// template before shipping this to production.
+type MetricsMiddleware struct {
+	registry     *prometheus.Registry
+	requestTotal *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	routeLabel   func(r *http.Request, vars map[string]string) string
+}
+
+// NewMetricsMiddleware returns a MetricsMiddleware with its own Prometheus
+// registry. buckets configures the http_request_duration_seconds
+// histogram; pass nil to use DefaultMetricsBuckets.
+//
+// The "path" label defaults to the raw request URL; call RouteLabel before
+// serving traffic to avoid an unbounded number of time series.
+func NewMetricsMiddleware(buckets []float64) *MetricsMiddleware {
+	if buckets == nil {
+		buckets = DefaultMetricsBuckets
+	}
+
+	registry := prometheus.NewRegistry()
+
+	requestTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests handled.",
+	}, []string{"method", "path", "status"})
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests in seconds.",
+		Buckets: buckets,
+	}, []string{"method", "path", "status"})
+
+	responseSize := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "Size of HTTP responses in bytes.",
+		Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+	}, []string{"method", "path", "status"})
+
+	registry.MustRegister(requestTotal, duration, responseSize)
+
+	return &MetricsMiddleware{
+		registry:     registry,
+		requestTotal: requestTotal,
+		duration:     duration,
+		responseSize: responseSize,
+	}
+}
+
+// RouteLabel installs a hook used to derive the "path" label from a
+// request's registered route template (e.g. "/users/:id") rather than its
+// raw URL, so high-cardinality paths with embedded ids don't blow up
+// cardinality. vars is the same map HandlerFunc already threads through
+// the handler chain.
+func (m *MetricsMiddleware) RouteLabel(f func(r *http.Request, vars map[string]string) string) *MetricsMiddleware {
+	m.routeLabel = f
+	return m
+}
+
+// Handler returns the promhttp endpoint for this middleware's registry, so
+// callers can mount it (e.g. at /metrics) without wiring a second
+// dependency.
+func (m *MetricsMiddleware) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *MetricsMiddleware) Apply(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		before := time.Now()
+
+		rw := negroni.NewResponseWriter(w)
+		err := next(rw, r, vars)
+
+		status := rw.Status()
+		if status == 0 {
+			status = 200
+		}
+
+		path := r.URL.Path
+		if m.routeLabel != nil {
+			path = m.routeLabel(r, vars)
+		}
+
+		labels := prometheus.Labels{
+			"method": r.Method,
+			"path":   path,
+			"status": strconv.Itoa(status),
+		}
+
+		m.requestTotal.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(before).Seconds())
+		m.responseSize.With(labels).Observe(float64(rw.Size()))
+
+		return err
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func okHandler() HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+}
+
+func TestMetricsMiddlewareDefaultsToRawPathLabel(t *testing.T) {
+	m := NewMetricsMiddleware(nil)
+	chain := m.Apply(okHandler())
+
+	for _, id := range []string{"123", "456", "789"} {
+		r, err := http.NewRequest(http.MethodGet, "/users/"+id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chain(httptest.NewRecorder(), r, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without RouteLabel, each distinct URL is its own time series - the
+	// cardinality-explosion behaviour the struct/constructor docs warn
+	// about.
+	if got := testutil.CollectAndCount(m.requestTotal); got != 3 {
+		t.Errorf("time series count = %d, want 3 (one per raw path)", got)
+	}
+}
+
+func TestMetricsMiddlewareRouteLabelCollapsesPath(t *testing.T) {
+	m := NewMetricsMiddleware(nil)
+	m.RouteLabel(func(r *http.Request, vars map[string]string) string {
+		return vars["route"]
+	})
+	chain := m.Apply(okHandler())
+
+	for _, id := range []string{"123", "456", "789"} {
+		r, err := http.NewRequest(http.MethodGet, "/users/"+id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vars := map[string]string{"route": "/users/:id"}
+		if err := chain(httptest.NewRecorder(), r, vars); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := testutil.CollectAndCount(m.requestTotal); got != 1 {
+		t.Errorf("time series count = %d, want 1 (routes collapsed via RouteLabel)", got)
+	}
+
+	if got := testutil.ToFloat64(m.requestTotal.WithLabelValues("GET", "/users/:id", "200")); got != 3 {
+		t.Errorf("http_requests_total{method=GET,path=/users/:id,status=200} = %v, want 3", got)
+	}
+}
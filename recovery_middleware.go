@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"gopkg.in/errgo.v1"
+
+	"github.com/codegangsta/negroni"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRecoveryFrames bounds the traceback collected for a recovered panic.
+const maxRecoveryFrames = 32
+
+// RecoveryMiddleware recovers panics raised by downstream handlers, logs
+// them at ErrorLevel together with a bounded stack traceback, and makes
+// sure a response is still written. Without it, a panic in next kills the
+// goroutine and any "request completed" log line from LoggingMiddleware is
+// never emitted.
+//
+// Mount it closer to the handler than LoggingMiddleware, i.e.
+// LoggingMiddleware.Apply(RecoveryMiddleware.Apply(handler)). Mounted the
+// other way round, LoggingMiddleware never gets a chance to see the panic
+// unwind back through it, so its "request completed" line is skipped and
+// the panic is logged without the request-scoped fields (request_id among
+// them) LoggingMiddleware attaches to the context.
+type RecoveryMiddleware struct {
+	logger Logger
+}
+
+// NewRecoveryMiddleware returns a RecoveryMiddleware backed by logger. It is
+// a thin wrapper around NewRecoveryMiddlewareWithLogger kept for backwards
+// compatibility with callers that only have a logrus.FieldLogger.
+func NewRecoveryMiddleware(logger logrus.FieldLogger) *RecoveryMiddleware {
+	return NewRecoveryMiddlewareWithLogger(NewLogrusLogger(logger))
+}
+
+// NewRecoveryMiddlewareWithLogger returns a RecoveryMiddleware backed by any
+// Logger implementation.
+func NewRecoveryMiddlewareWithLogger(logger Logger) *RecoveryMiddleware {
+	return &RecoveryMiddleware{logger: logger}
+}
+
+func (m *RecoveryMiddleware) Apply(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, vars map[string]string) (err error) {
+		rw := negroni.NewResponseWriter(w)
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			logger := m.logger
+			if l, ok := LoggerFromContext(r.Context()); ok {
+				logger = l
+			}
+			logger.With(map[string]interface{}{"stack": recoveryStack()}).Errorf("panic recovered: %v", rec)
+
+			if rw.Status() == 0 {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+
+			if recErr, ok := rec.(error); ok {
+				err = errgo.Notef(recErr, "panic in handler")
+			} else {
+				err = errgo.Newf("panic in handler: %v", rec)
+			}
+		}()
+
+		return next(rw, r, vars)
+	}
+}
+
+// recoveryStack collects up to maxRecoveryFrames caller frames above
+// recover(), formatted as "func@file:line" one per line.
+func recoveryStack() string {
+	pcs := make([]uintptr, maxRecoveryFrames)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var lines []string
+	for {
+		frame, more := frames.Next()
+		lines = append(lines, fmt.Sprintf("%s@%s:%d", frame.Function, frame.File, frame.Line))
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordedLogCall captures one Logger call together with the fields
+// accumulated on it via With, so tests can assert on both the message and
+// the request-scoped context it carried.
+type recordedLogCall struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+type fakeLogger struct {
+	calls  *[]recordedLogCall
+	fields map[string]interface{}
+}
+
+func newFakeLogger() *fakeLogger {
+	return &fakeLogger{calls: &[]recordedLogCall{}, fields: map[string]interface{}{}}
+}
+
+func (f *fakeLogger) record(level, format string, args []interface{}) {
+	*f.calls = append(*f.calls, recordedLogCall{level: level, msg: fmt.Sprintf(format, args...), fields: f.fields})
+}
+
+func (f *fakeLogger) Debugf(format string, args ...interface{}) { f.record("debug", format, args) }
+func (f *fakeLogger) Infof(format string, args ...interface{})  { f.record("info", format, args) }
+func (f *fakeLogger) Warnf(format string, args ...interface{})  { f.record("warn", format, args) }
+func (f *fakeLogger) Errorf(format string, args ...interface{}) { f.record("error", format, args) }
+
+func (f *fakeLogger) With(fields map[string]interface{}) Logger {
+	merged := make(map[string]interface{}, len(f.fields)+len(fields))
+	for k, v := range f.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &fakeLogger{calls: f.calls, fields: merged}
+}
+
+// TestRecoveryMiddlewareMountedInsideLogging verifies the mounting order
+// documented on RecoveryMiddleware: LoggingMiddleware.Apply(
+// RecoveryMiddleware.Apply(handler)) must still emit "request completed"
+// and carry request_id on the recovered-panic log line.
+func TestRecoveryMiddlewareMountedInsideLogging(t *testing.T) {
+	logger := newFakeLogger()
+
+	logging := NewLoggingMiddlewareWithLogger(logger)
+	recovery := NewRecoveryMiddlewareWithLogger(logger)
+
+	panicking := HandlerFunc(func(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		panic("boom")
+	})
+
+	chain := logging.Apply(recovery.Apply(panicking))
+
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("X-Request-ID", "req-42")
+	w := httptest.NewRecorder()
+
+	if err := chain(w, r, nil); err == nil {
+		t.Fatal("expected the recovered panic to surface as an error")
+	}
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	var sawPanicLog, sawRequestCompleted bool
+	for _, call := range *logger.calls {
+		if call.msg == "panic recovered: boom" {
+			sawPanicLog = true
+			if call.fields["request_id"] != "req-42" {
+				t.Errorf("panic log call fields = %v, want request_id=req-42", call.fields)
+			}
+		}
+		if call.msg == "request completed" {
+			sawRequestCompleted = true
+			if call.fields["request_id"] != "req-42" {
+				t.Errorf("request completed call fields = %v, want request_id=req-42", call.fields)
+			}
+		}
+	}
+
+	if !sawPanicLog {
+		t.Error("expected a \"panic recovered\" log call")
+	}
+	if !sawRequestCompleted {
+		t.Error(`expected "request completed" to still be logged after the panic`)
+	}
+}
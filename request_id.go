@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// requestIDFromHeaders looks for a request id on common tracing headers, in
+// order of preference: X-Request-ID, X-Correlation-ID, and the trace-id
+// segment of a W3C traceparent header.
+func requestIDFromHeaders(r *http.Request) (string, bool) {
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		return id, true
+	}
+	if id := r.Header.Get("X-Correlation-ID"); id != "" {
+		return id, true
+	}
+	if tp := r.Header.Get("traceparent"); tp != "" {
+		// version-traceid-parentid-flags
+		parts := strings.Split(tp, "-")
+		if len(parts) >= 2 && parts[1] != "" {
+			return parts[1], true
+		}
+	}
+	return "", false
+}
+
+// newRequestID generates a random UUIDv4, used when no tracing header is
+// present on the incoming request.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand.Read only fails if the OS source is unusable, which
+		// would be a fatal condition for anything relying on randomness;
+		// degrade to a fixed, clearly-non-unique id rather than panicking.
+		return "00000000-0000-0000-0000-000000000000"
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestRequestIDFromHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+		wantOk  bool
+	}{
+		{
+			name:    "no tracing headers",
+			headers: map[string]string{},
+			wantOk:  false,
+		},
+		{
+			name:    "X-Request-ID takes precedence over everything else",
+			headers: map[string]string{"X-Request-ID": "req-1", "X-Correlation-ID": "corr-1", "traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			want:    "req-1",
+			wantOk:  true,
+		},
+		{
+			name:    "X-Correlation-ID used when X-Request-ID absent",
+			headers: map[string]string{"X-Correlation-ID": "corr-1", "traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			want:    "corr-1",
+			wantOk:  true,
+		},
+		{
+			name:    "traceparent trace-id used as last resort",
+			headers: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			want:    "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantOk:  true,
+		},
+		{
+			name:    "traceparent without dashes is ignored",
+			headers: map[string]string{"traceparent": "notraceparent"},
+			wantOk:  false,
+		},
+		{
+			name:    "traceparent with empty trace-id segment is ignored",
+			headers: map[string]string{"traceparent": "00--00f067aa0ba902b7-01"},
+			wantOk:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			got, ok := requestIDFromHeaders(r)
+			if ok != tt.wantOk {
+				t.Fatalf("requestIDFromHeaders() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got != tt.want {
+				t.Errorf("requestIDFromHeaders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var uuidv4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewRequestIDIsAUUIDv4(t *testing.T) {
+	id := newRequestID()
+	if !uuidv4Pattern.MatchString(id) {
+		t.Errorf("newRequestID() = %q, want a UUIDv4", id)
+	}
+}
+
+func TestNewRequestIDIsUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := newRequestID()
+		if seen[id] {
+			t.Fatalf("newRequestID() returned a duplicate: %q", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger adapts a *slog.Logger to the Logger interface, letting
+// callers on Go 1.21+ use the standard library's structured logger instead
+// of pulling logrus into their binary.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) With(fields map[string]interface{}) Logger {
+	args := make([]interface{}, 0, len(fields)*2)
+	for k, v := range fields {
+		args = append(args, k, v)
+	}
+	return &slogLogger{logger: l.logger.With(args...)}
+}